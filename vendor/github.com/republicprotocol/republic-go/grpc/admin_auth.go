@@ -0,0 +1,39 @@
+package grpc
+
+import (
+	"errors"
+	"net"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/peer"
+)
+
+// ErrAdminUnauthorized is returned when an operator RPC (Connect, Disconnect,
+// Peers, KnownAddrs) is called by a peer that is not permitted to administer
+// this node.
+var ErrAdminUnauthorized = errors.New("unauthorized: admin rpcs are restricted to loopback callers")
+
+// authorizeAdmin restricts operator RPCs to callers connecting over
+// loopback. SwarmService is registered on the same gRPC listener that every
+// other darknode dials for Ping/Pong/Query, so without this check any peer
+// on the network could force a Connect/Disconnect against an arbitrary
+// address or dump Peers/KnownAddrs for reconnaissance. An operator CLI is
+// expected to reach these RPCs via an SSH tunnel or a loopback-bound admin
+// listener, not the public-facing one.
+func authorizeAdmin(ctx context.Context) error {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ErrAdminUnauthorized
+	}
+
+	host := p.Addr.String()
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !ip.IsLoopback() {
+		return ErrAdminUnauthorized
+	}
+	return nil
+}
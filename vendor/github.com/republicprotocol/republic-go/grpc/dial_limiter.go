@@ -0,0 +1,219 @@
+package grpc
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/republicprotocol/republic-go/identity"
+	"golang.org/x/net/context"
+	grpcgo "google.golang.org/grpc"
+)
+
+// DefaultDialLimiterGlobalCapacity is the default number of dials a
+// DialLimiter will allow in flight across all peers at once.
+const DefaultDialLimiterGlobalCapacity = 160
+
+// DefaultDialLimiterPeerCapacity is the default number of dials a
+// DialLimiter will allow in flight to a single peer at once.
+const DefaultDialLimiterPeerCapacity = 8
+
+// DialFunc dials an identity.MultiAddress and returns a gRPC client
+// connection, matching the signature of the package-level Dial function.
+type DialFunc func(ctx context.Context, addr identity.MultiAddress) (*grpcgo.ClientConn, error)
+
+type dialResult struct {
+	conn *grpcgo.ClientConn
+	err  error
+}
+
+type dialJob struct {
+	ctx  context.Context
+	peer identity.Address
+	addr identity.MultiAddress
+	resp chan dialResult
+}
+
+// DialLimiter bounds the number of concurrent outbound dials a darknode will
+// attempt, so that a Query fan-out or a burst of re-pings cannot exhaust its
+// file descriptors. It holds a global token bucket for the total number of
+// in-flight dials and a per-peer token bucket, and queues excess dial
+// requests per-peer in FIFO order. When a dial completes and its peer's
+// queue is empty, the freed tokens are handed to the next eligible job from
+// any other peer's queue, so one slow or hanging peer cannot starve dials to
+// everyone else.
+type DialLimiter struct {
+	dial DialFunc
+
+	globalCapacity int
+	peerCapacity   int
+
+	mu       sync.Mutex
+	inFlight map[identity.Address]int
+	global   int
+	queues   map[identity.Address][]dialJob
+
+	queued   prometheus.Gauge
+	active   prometheus.Gauge
+	rejected prometheus.Counter
+}
+
+// NewDialLimiter returns a DialLimiter that calls dial to perform the
+// underlying connection attempt, admitting at most globalCapacity dials
+// across all peers and peerCapacity dials to any single peer at once. A
+// non-positive globalCapacity or peerCapacity falls back to its default. If
+// registerer is non-nil, the limiter's Prometheus collectors are registered
+// with it.
+func NewDialLimiter(dial DialFunc, globalCapacity, peerCapacity int, registerer prometheus.Registerer) *DialLimiter {
+	if globalCapacity <= 0 {
+		globalCapacity = DefaultDialLimiterGlobalCapacity
+	}
+	if peerCapacity <= 0 {
+		peerCapacity = DefaultDialLimiterPeerCapacity
+	}
+
+	limiter := &DialLimiter{
+		dial: dial,
+
+		globalCapacity: globalCapacity,
+		peerCapacity:   peerCapacity,
+
+		inFlight: map[identity.Address]int{},
+		queues:   map[identity.Address][]dialJob{},
+
+		queued: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "republic",
+			Subsystem: "swarm",
+			Name:      "dial_limiter_queued",
+			Help:      "Number of dials queued by the DialLimiter, waiting for a token.",
+		}),
+		active: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "republic",
+			Subsystem: "swarm",
+			Name:      "dial_limiter_active",
+			Help:      "Number of dials currently in flight under the DialLimiter.",
+		}),
+		rejected: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "republic",
+			Subsystem: "swarm",
+			Name:      "dial_limiter_rejected_total",
+			Help:      "Number of dials cancelled by their caller while still queued.",
+		}),
+	}
+	if registerer != nil {
+		registerer.MustRegister(limiter.queued, limiter.active, limiter.rejected)
+	}
+	return limiter
+}
+
+// Dial queues a dial to addr on behalf of peer and blocks until a token is
+// available and the dial completes, or ctx is done. It is safe to call
+// concurrently.
+func (limiter *DialLimiter) Dial(ctx context.Context, peer identity.Address, addr identity.MultiAddress) (*grpcgo.ClientConn, error) {
+	job := dialJob{
+		ctx:  ctx,
+		peer: peer,
+		addr: addr,
+		resp: make(chan dialResult, 1),
+	}
+
+	limiter.mu.Lock()
+	limiter.queues[peer] = append(limiter.queues[peer], job)
+	limiter.queued.Inc()
+	limiter.scheduleLocked()
+	limiter.mu.Unlock()
+
+	select {
+	case res := <-job.resp:
+		return res.conn, res.err
+	case <-ctx.Done():
+		limiter.dequeue(job)
+		return nil, ctx.Err()
+	}
+}
+
+// scheduleLocked admits queued jobs while the global token bucket and the
+// relevant peer's token bucket both have capacity. Callers must hold
+// limiter.mu.
+func (limiter *DialLimiter) scheduleLocked() {
+	for limiter.global < limiter.globalCapacity {
+		peer, job, ok := limiter.popEligibleLocked()
+		if !ok {
+			return
+		}
+		limiter.global++
+		limiter.inFlight[peer]++
+		limiter.queued.Dec()
+		limiter.active.Inc()
+		go limiter.run(peer, job)
+	}
+}
+
+// popEligibleLocked scans every peer's queue for the first job whose peer
+// has spare per-peer capacity, removes it from that queue, and returns it.
+// Scanning every peer (rather than only the job's own peer) is what
+// redistributes a freed per-peer token to another peer's queue once a given
+// peer has nothing left to dial. Callers must hold limiter.mu.
+func (limiter *DialLimiter) popEligibleLocked() (identity.Address, dialJob, bool) {
+	for peer, queue := range limiter.queues {
+		if len(queue) == 0 || limiter.inFlight[peer] >= limiter.peerCapacity {
+			continue
+		}
+		job := queue[0]
+		if len(queue) == 1 {
+			delete(limiter.queues, peer)
+		} else {
+			limiter.queues[peer] = queue[1:]
+		}
+		return peer, job, true
+	}
+	return "", dialJob{}, false
+}
+
+func (limiter *DialLimiter) run(peer identity.Address, job dialJob) {
+	conn, err := limiter.dial(job.ctx, job.addr)
+
+	// If job.ctx is already done, Dial gave up waiting on job.resp once it
+	// returned via the ctx.Done() case, so nobody will ever read this send.
+	// Close a successful conn ourselves rather than leaking its file
+	// descriptor into a channel nobody drains.
+	if job.ctx.Err() != nil {
+		if conn != nil {
+			conn.Close()
+		}
+	} else {
+		job.resp <- dialResult{conn: conn, err: err}
+	}
+
+	limiter.mu.Lock()
+	limiter.global--
+	limiter.inFlight[peer]--
+	if limiter.inFlight[peer] <= 0 {
+		delete(limiter.inFlight, peer)
+	}
+	limiter.active.Dec()
+	limiter.scheduleLocked()
+	limiter.mu.Unlock()
+}
+
+// dequeue removes job from its peer's queue if it has not yet been
+// dispatched, decrementing the queued gauge and counting the dial as
+// rejected; a queued job holds no token, so none is returned. If job has already
+// been dispatched, this is a no-op; its result is silently discarded when it
+// arrives, since job.resp is buffered.
+func (limiter *DialLimiter) dequeue(job dialJob) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	queue := limiter.queues[job.peer]
+	for i, queued := range queue {
+		if queued.resp == job.resp {
+			limiter.queues[job.peer] = append(queue[:i], queue[i+1:]...)
+			if len(limiter.queues[job.peer]) == 0 {
+				delete(limiter.queues, job.peer)
+			}
+			limiter.queued.Dec()
+			limiter.rejected.Inc()
+			return
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package grpc
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/republicprotocol/republic-go/identity"
+	"golang.org/x/net/context"
+	grpcgo "google.golang.org/grpc"
+)
+
+// TestDialLimiterRedistributesFreedTokenAcrossPeers checks that once a
+// peer's queue drains, the global token it held is handed to another peer's
+// queued dial instead of idling until that same peer enqueues more work.
+func TestDialLimiterRedistributesFreedTokenAcrossPeers(t *testing.T) {
+	peerA := identity.Address("peerA")
+	peerB := identity.Address("peerB")
+
+	var calls int32
+	releaseFirst := make(chan struct{})
+	dial := func(ctx context.Context, addr identity.MultiAddress) (*grpcgo.ClientConn, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-releaseFirst
+		}
+		return nil, nil
+	}
+
+	limiter := NewDialLimiter(dial, 1, 1, nil)
+
+	doneA := make(chan error, 1)
+	go func() {
+		_, err := limiter.Dial(context.Background(), peerA, identity.MultiAddress{})
+		doneA <- err
+	}()
+	for atomic.LoadInt32(&calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	doneB := make(chan error, 1)
+	go func() {
+		_, err := limiter.Dial(context.Background(), peerB, identity.MultiAddress{})
+		doneB <- err
+	}()
+
+	// The sole global token is held by peerA's in-flight dial, so peerB's
+	// dial must not be scheduled yet.
+	select {
+	case <-doneB:
+		t.Fatal("peerB's dial completed before peerA released the global token")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(releaseFirst)
+	if err := <-doneA; err != nil {
+		t.Fatalf("peerA's dial returned an error: %v", err)
+	}
+
+	select {
+	case err := <-doneB:
+		if err != nil {
+			t.Fatalf("peerB's dial returned an error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("peerB's dial was never scheduled after peerA's queue drained")
+	}
+
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Fatalf("expected 2 dial attempts, got %d", got)
+	}
+}
+
+// TestDialLimiterCancelDequeuesStillQueuedJob checks that cancelling a
+// still-queued dial returns an error and removes the dial from its peer's
+// queue, rather than leaving a phantom entry that would block that peer's
+// later dials.
+func TestDialLimiterCancelDequeuesStillQueuedJob(t *testing.T) {
+	peerAddr := identity.Address("peer")
+
+	block := make(chan struct{})
+	dial := func(ctx context.Context, addr identity.MultiAddress) (*grpcgo.ClientConn, error) {
+		<-block
+		return nil, nil
+	}
+
+	limiter := NewDialLimiter(dial, 1, 1, nil)
+
+	// Occupy the only global token so the next dial must queue.
+	go limiter.Dial(context.Background(), peerAddr, identity.MultiAddress{})
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error, 1)
+	go func() {
+		_, err := limiter.Dial(ctx, peerAddr, identity.MultiAddress{})
+		cancelled <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-cancelled:
+		if err == nil {
+			t.Fatal("expected the cancelled dial to return an error")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("cancelled dial never returned")
+	}
+
+	limiter.mu.Lock()
+	queueLen := len(limiter.queues[peerAddr])
+	limiter.mu.Unlock()
+	if queueLen != 0 {
+		t.Fatalf("expected the cancelled dial to be removed from the queue, got %d entries", queueLen)
+	}
+
+	close(block)
+}
@@ -0,0 +1,174 @@
+package grpc
+
+import (
+	"container/list"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/republicprotocol/republic-go/identity"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/peer"
+)
+
+// DefaultRateLimiterRate is the default number of requests per second a
+// tokenBucketRateLimiter allows a single (peer, RPC) pair to sustain.
+const DefaultRateLimiterRate = 10
+
+// DefaultRateLimiterBurst is the default number of requests a
+// tokenBucketRateLimiter allows a single (peer, RPC) pair to burst to.
+const DefaultRateLimiterBurst = 20
+
+// DefaultGlobalRateLimiterRate is the default number of requests per second
+// a tokenBucketRateLimiter allows across all peers and RPCs combined.
+const DefaultGlobalRateLimiterRate = 500
+
+// DefaultGlobalRateLimiterBurst is the default number of requests a
+// tokenBucketRateLimiter allows all peers and RPCs combined to burst to.
+const DefaultGlobalRateLimiterBurst = 1000
+
+// DefaultRateLimiterLRUSize bounds the number of per-(peer, RPC) token
+// buckets a tokenBucketRateLimiter keeps in memory at once.
+const DefaultRateLimiterLRUSize = 4096
+
+// RateLimiter decides whether a SwarmService should process an RPC call
+// from peer. rpc is the name of the RPC being called, e.g. "Ping".
+type RateLimiter interface {
+	Allow(peer identity.Address, rpc string) bool
+}
+
+// tokenBucket is a simple token bucket: tokens refill continuously at rate
+// tokens per second, up to burst, and Allow consumes one token if available.
+type tokenBucket struct {
+	mu       sync.Mutex
+	rate     float64
+	burst    float64
+	tokens   float64
+	lastFill time.Time
+}
+
+func newTokenBucket(rate, burst float64) *tokenBucket {
+	return &tokenBucket{
+		rate:     rate,
+		burst:    burst,
+		tokens:   burst,
+		lastFill: time.Now(),
+	}
+}
+
+func (bucket *tokenBucket) Allow() bool {
+	bucket.mu.Lock()
+	defer bucket.mu.Unlock()
+
+	now := time.Now()
+	bucket.tokens += now.Sub(bucket.lastFill).Seconds() * bucket.rate
+	if bucket.tokens > bucket.burst {
+		bucket.tokens = bucket.burst
+	}
+	bucket.lastFill = now
+
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+type rateLimitKey struct {
+	peer identity.Address
+	rpc  string
+}
+
+type rateLimitEntry struct {
+	key    rateLimitKey
+	bucket *tokenBucket
+}
+
+// tokenBucketRateLimiter is the default RateLimiter. It keeps one token
+// bucket per (peer, RPC) pair, bounded to maxSize entries (by default
+// DefaultRateLimiterLRUSize) by evicting the least-recently-used pair, plus
+// a single global token
+// bucket shared by every request.
+type tokenBucketRateLimiter struct {
+	rate  float64
+	burst float64
+
+	global *tokenBucket
+
+	mu      sync.Mutex
+	maxSize int
+	order   *list.List
+	entries map[rateLimitKey]*list.Element
+}
+
+// NewTokenBucketRateLimiter returns a RateLimiter where each (peer, RPC)
+// pair is allowed rate requests per second, up to a burst of burst, subject
+// to a global limit of globalRate requests per second up to globalBurst. At
+// most maxSize (peer, RPC) buckets are retained; a non-positive maxSize
+// falls back to DefaultRateLimiterLRUSize.
+func NewTokenBucketRateLimiter(rate, burst, globalRate, globalBurst float64, maxSize int) RateLimiter {
+	if maxSize <= 0 {
+		maxSize = DefaultRateLimiterLRUSize
+	}
+	return &tokenBucketRateLimiter{
+		rate:    rate,
+		burst:   burst,
+		global:  newTokenBucket(globalRate, globalBurst),
+		maxSize: maxSize,
+		order:   list.New(),
+		entries: map[rateLimitKey]*list.Element{},
+	}
+}
+
+// Allow implements the RateLimiter interface.
+func (limiter *tokenBucketRateLimiter) Allow(peerAddr identity.Address, rpc string) bool {
+	if !limiter.global.Allow() {
+		return false
+	}
+	return limiter.bucketFor(peerAddr, rpc).Allow()
+}
+
+func (limiter *tokenBucketRateLimiter) bucketFor(peerAddr identity.Address, rpc string) *tokenBucket {
+	key := rateLimitKey{peer: peerAddr, rpc: rpc}
+
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	if elem, ok := limiter.entries[key]; ok {
+		limiter.order.MoveToFront(elem)
+		return elem.Value.(*rateLimitEntry).bucket
+	}
+
+	bucket := newTokenBucket(limiter.rate, limiter.burst)
+	elem := limiter.order.PushFront(&rateLimitEntry{key: key, bucket: bucket})
+	limiter.entries[key] = elem
+
+	if limiter.order.Len() > limiter.maxSize {
+		oldest := limiter.order.Back()
+		if oldest != nil {
+			limiter.order.Remove(oldest)
+			delete(limiter.entries, oldest.Value.(*rateLimitEntry).key)
+		}
+	}
+	return bucket
+}
+
+// peerAddressFromContext returns an identity.Address derived from the
+// source IP gRPC associates with ctx, with any port stripped. It is used to
+// rate-limit RPCs, such as Query, whose request messages do not carry a
+// signed identity.MultiAddress for the caller. The port must be stripped:
+// it is the ephemeral client port of a single TCP connection, so keying on
+// the full host:port would let a caller evade its per-peer bucket simply by
+// reconnecting for every request.
+func peerAddressFromContext(ctx context.Context) identity.Address {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return identity.Address("")
+	}
+
+	host := p.Addr.String()
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return identity.Address(host)
+}
@@ -0,0 +1,80 @@
+package grpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/republicprotocol/republic-go/identity"
+)
+
+func TestTokenBucketRateLimiterDeniesOnceBurstExhausted(t *testing.T) {
+	limiter := NewTokenBucketRateLimiter(1, 2, 1000, 1000, 0)
+	peerAddr := identity.Address("peer")
+
+	if !limiter.Allow(peerAddr, "Ping") {
+		t.Fatal("expected the first request within the burst to be allowed")
+	}
+	if !limiter.Allow(peerAddr, "Ping") {
+		t.Fatal("expected the second request within the burst to be allowed")
+	}
+	if limiter.Allow(peerAddr, "Ping") {
+		t.Fatal("expected a request beyond the burst to be denied")
+	}
+}
+
+func TestTokenBucketRateLimiterRefillsOverTime(t *testing.T) {
+	limiter := NewTokenBucketRateLimiter(1000, 1, 1000, 1000, 0)
+	peerAddr := identity.Address("peer")
+
+	if !limiter.Allow(peerAddr, "Ping") {
+		t.Fatal("expected the first request to be allowed")
+	}
+	if limiter.Allow(peerAddr, "Ping") {
+		t.Fatal("expected the bucket to be exhausted immediately after the burst")
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if !limiter.Allow(peerAddr, "Ping") {
+		t.Fatal("expected the bucket to have refilled after waiting")
+	}
+}
+
+func TestTokenBucketRateLimiterEvictsLeastRecentlyUsed(t *testing.T) {
+	limiter := NewTokenBucketRateLimiter(1, 1, 1000, 1000, 2).(*tokenBucketRateLimiter)
+
+	limiter.Allow(identity.Address("peerA"), "Ping")
+	limiter.Allow(identity.Address("peerB"), "Ping")
+	// peerA is now the least-recently-used entry; inserting a third key must
+	// evict it rather than peerB.
+	limiter.Allow(identity.Address("peerC"), "Ping")
+
+	limiter.mu.Lock()
+	_, hasA := limiter.entries[rateLimitKey{peer: identity.Address("peerA"), rpc: "Ping"}]
+	_, hasB := limiter.entries[rateLimitKey{peer: identity.Address("peerB"), rpc: "Ping"}]
+	_, hasC := limiter.entries[rateLimitKey{peer: identity.Address("peerC"), rpc: "Ping"}]
+	size := len(limiter.entries)
+	limiter.mu.Unlock()
+
+	if hasA {
+		t.Fatal("expected peerA's bucket to have been evicted as least-recently-used")
+	}
+	if !hasB || !hasC {
+		t.Fatal("expected peerB's and peerC's buckets to remain")
+	}
+	if size != 2 {
+		t.Fatalf("expected the LRU to hold at most 2 entries, got %d", size)
+	}
+}
+
+func TestTokenBucketRateLimiterGlobalBucketEnforcedIndependently(t *testing.T) {
+	// Each peer has ample per-peer budget, but the shared global bucket only
+	// allows a single request across all of them.
+	limiter := NewTokenBucketRateLimiter(1000, 1000, 1, 1, 0)
+
+	if !limiter.Allow(identity.Address("peerA"), "Ping") {
+		t.Fatal("expected the first request to be allowed under the global bucket")
+	}
+	if limiter.Allow(identity.Address("peerB"), "Ping") {
+		t.Fatal("expected a different peer's request to be denied once the global bucket is exhausted")
+	}
+}
@@ -0,0 +1,147 @@
+package grpc
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/republicprotocol/republic-go/identity"
+	"golang.org/x/net/context"
+)
+
+// DefaultResolverTTL is the default duration for which a MultiaddrResolver
+// caches a DNS lookup before resolving the hostname again.
+const DefaultResolverTTL = 5 * time.Minute
+
+// dnsProtocols is the set of multiaddr protocol names that require DNS
+// resolution before dialling. dnsaddr resolution (TXT-record based, as used
+// by libp2p bootstrap lists) is approximated here as a plain lookup of the
+// hostname, keeping only its A (IPv4) records.
+var dnsProtocols = map[string]bool{
+	"dns":     true,
+	"dns4":    true,
+	"dns6":    true,
+	"dnsaddr": true,
+}
+
+// MultiaddrResolver expands an identity.MultiAddress that may contain a
+// hostname (a /dns4, /dns6, /dns, or /dnsaddr component) into one or more
+// identity.MultiAddresses with the hostname replaced by a resolved IP
+// address. Implementations should return addr unchanged, wrapped in a
+// single-element slice, when it does not require resolution.
+type MultiaddrResolver interface {
+	Resolve(ctx context.Context, addr identity.MultiAddress) ([]identity.MultiAddress, error)
+}
+
+type dnsCacheEntry struct {
+	ips    []net.IP
+	expiry time.Time
+}
+
+// dnsMultiaddrResolver is the default MultiaddrResolver. It resolves
+// hostnames using net.DefaultResolver and caches the result, keyed by
+// hostname, for a configurable TTL.
+type dnsMultiaddrResolver struct {
+	resolver *net.Resolver
+	ttl      time.Duration
+
+	mu    sync.Mutex
+	cache map[string]dnsCacheEntry
+}
+
+// NewDNSMultiaddrResolver returns a MultiaddrResolver that resolves /dns,
+// /dns4, /dns6, and /dnsaddr components using net.DefaultResolver, caching
+// lookups for ttl. A non-positive ttl falls back to DefaultResolverTTL.
+func NewDNSMultiaddrResolver(ttl time.Duration) MultiaddrResolver {
+	if ttl <= 0 {
+		ttl = DefaultResolverTTL
+	}
+	return &dnsMultiaddrResolver{
+		resolver: net.DefaultResolver,
+		ttl:      ttl,
+		cache:    map[string]dnsCacheEntry{},
+	}
+}
+
+// Resolve implements the MultiaddrResolver interface.
+func (r *dnsMultiaddrResolver) Resolve(ctx context.Context, addr identity.MultiAddress) ([]identity.MultiAddress, error) {
+	parts := strings.Split(strings.TrimPrefix(addr.String(), "/"), "/")
+	if len(parts) < 2 {
+		return []identity.MultiAddress{addr}, nil
+	}
+
+	proto, host := parts[0], parts[1]
+	if !dnsProtocols[proto] {
+		return []identity.MultiAddress{addr}, nil
+	}
+
+	ips, err := r.lookup(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve %v: %v", host, err)
+	}
+
+	// /dns4 (and, as approximated here, /dnsaddr) only accepts A records;
+	// /dns6 only accepts AAAA records; /dns accepts either.
+	wantIPv4, wantIPv6 := true, true
+	switch proto {
+	case "dns4", "dnsaddr":
+		wantIPv6 = false
+	case "dns6":
+		wantIPv4 = false
+	}
+
+	rest := strings.Join(parts[2:], "/")
+	resolved := make([]identity.MultiAddress, 0, len(ips))
+	for _, ip := range ips {
+		ipProto := "ip6"
+		if ip4 := ip.To4(); ip4 != nil {
+			ipProto = "ip4"
+			ip = ip4
+		}
+		if (ipProto == "ip4" && !wantIPv4) || (ipProto == "ip6" && !wantIPv6) {
+			continue
+		}
+
+		resolvedStr := fmt.Sprintf("/%v/%v", ipProto, ip)
+		if rest != "" {
+			resolvedStr = fmt.Sprintf("%v/%v", resolvedStr, rest)
+		}
+		multiAddr, err := identity.NewMultiAddressFromString(resolvedStr)
+		if err != nil {
+			continue
+		}
+		multiAddr.Signature = addr.Signature
+		multiAddr.Nonce = addr.Nonce
+		resolved = append(resolved, multiAddr)
+	}
+	if len(resolved) == 0 {
+		return nil, fmt.Errorf("no %v addresses found for %v", proto, host)
+	}
+	return resolved, nil
+}
+
+func (r *dnsMultiaddrResolver) lookup(ctx context.Context, host string) ([]net.IP, error) {
+	r.mu.Lock()
+	if entry, ok := r.cache[host]; ok && time.Now().Before(entry.expiry) {
+		ips := entry.ips
+		r.mu.Unlock()
+		return ips, nil
+	}
+	r.mu.Unlock()
+
+	addrs, err := r.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]net.IP, len(addrs))
+	for i, a := range addrs {
+		ips[i] = a.IP
+	}
+
+	r.mu.Lock()
+	r.cache[host] = dnsCacheEntry{ips: ips, expiry: time.Now().Add(r.ttl)}
+	r.mu.Unlock()
+	return ips, nil
+}
@@ -0,0 +1,80 @@
+package grpc
+
+import (
+	"testing"
+
+	"github.com/republicprotocol/republic-go/identity"
+)
+
+func mustMultiAddress(t *testing.T, addr identity.Address) identity.MultiAddress {
+	multiAddr, err := identity.NewMultiAddressFromString("/republic/" + string(addr))
+	if err != nil {
+		t.Fatalf("cannot build test multiaddress for %v: %v", addr, err)
+	}
+	return multiAddr
+}
+
+func TestShortlistOrdersByXORDistanceToTarget(t *testing.T) {
+	target := identity.Address("target")
+	list := newShortlist(target, 3)
+
+	list.Insert(mustMultiAddress(t, target))
+	list.Insert(mustMultiAddress(t, identity.Address("far-away-node")))
+
+	addrs := list.MultiAddresses()
+	if len(addrs) != 2 {
+		t.Fatalf("expected 2 addresses in the shortlist, got %d", len(addrs))
+	}
+	if addrs[0].Address() != target {
+		t.Fatalf("expected the closest address (the target itself) first, got %v", addrs[0].Address())
+	}
+}
+
+func TestShortlistClosestDistanceConverges(t *testing.T) {
+	target := identity.Address("target")
+	list := newShortlist(target, 20)
+
+	if list.ClosestDistance().Cmp(maxXORDistance) != 0 {
+		t.Fatal("expected an empty shortlist to report the maximum distance")
+	}
+
+	list.Insert(mustMultiAddress(t, identity.Address("far-away-node")))
+	afterFar := list.ClosestDistance()
+	if afterFar.Cmp(maxXORDistance) >= 0 {
+		t.Fatal("expected inserting any node to reduce the closest distance below the maximum")
+	}
+
+	list.Insert(mustMultiAddress(t, target))
+	afterTarget := list.ClosestDistance()
+	if afterTarget.Sign() != 0 {
+		t.Fatalf("expected the distance to the target itself to be zero, got %v", afterTarget)
+	}
+	if afterTarget.Cmp(afterFar) >= 0 {
+		t.Fatal("expected inserting a strictly closer node to reduce the closest distance")
+	}
+}
+
+// TestQueryConvergenceStopsOnNoCloserRound is a regression test for the bug
+// where Query's round-termination check treated any previously-unseen
+// address as progress, even one immediately truncated out of a k-sized
+// shortlist for being farther than everything already tracked. It exercises
+// the same ClosestDistance comparison Query performs between rounds.
+func TestQueryConvergenceStopsOnNoCloserRound(t *testing.T) {
+	target := identity.Address("target")
+	list := newShortlist(target, 1)
+	list.Insert(mustMultiAddress(t, target))
+
+	before := list.ClosestDistance()
+
+	// A farther node is "novel" (previously unseen) but must not be treated
+	// as progress: it can't even fit in a k=1 shortlist that already holds
+	// the target itself.
+	if inserted := list.Insert(mustMultiAddress(t, identity.Address("far-away-node"))); !inserted {
+		t.Fatal("expected the novel address to report as inserted")
+	}
+
+	after := list.ClosestDistance()
+	if after.Cmp(before) != 0 {
+		t.Fatalf("expected a farther, truncated insert not to change the closest distance; before=%v after=%v", before, after)
+	}
+}
@@ -1,15 +1,33 @@
 package grpc
 
 import (
+	"crypto/sha256"
 	"errors"
 	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/republicprotocol/republic-go/identity"
 	"github.com/republicprotocol/republic-go/logger"
 	"github.com/republicprotocol/republic-go/swarm"
 	"golang.org/x/net/context"
+	grpcgo "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// DefaultQueryAlpha is the default number of concurrent QueryStream RPCs that
+// a swarmClient will keep in flight while performing an iterative lookup.
+const DefaultQueryAlpha = 3
+
+// DefaultQueryK is the default size of the shortlist of closest
+// identity.MultiAddresses that a swarmClient keeps while performing an
+// iterative lookup.
+const DefaultQueryK = 20
+
 // ErrRateLimitExceeded is returned when the same client sends more than one
 // request to the server within a specified rate limit.
 var ErrRateLimitExceeded = errors.New("cannot process request, rate limit exceeded")
@@ -33,26 +51,137 @@ var ErrMultiAddressIsNil = errors.New("multi-address is nil")
 // ErrAddressIsNil is returned when an address is nil.
 var ErrAddressIsNil = errors.New("address is nil")
 
+// PeerInfo describes a peer that a darknode currently considers live,
+// returned by the SwarmService Peers RPC.
+type PeerInfo struct {
+	MultiAddress identity.MultiAddress
+	LastSeen     time.Time
+	RTT          time.Duration
+}
+
+// SwarmAdminClient is a swarm.Client with additional operator RPCs for
+// driving and inspecting a remote darknode's view of the swarm. It is a
+// superset of swarm.Client, so it can be used anywhere a swarm.Client is
+// expected.
+type SwarmAdminClient interface {
+	swarm.Client
+
+	// Connect instructs to to immediately dial and Ping multiAddr.
+	Connect(ctx context.Context, to identity.MultiAddress, multiAddr identity.MultiAddress) error
+
+	// Disconnect instructs to to evict multiAddr from its MultiAddressStorer
+	// and close any pooled connection it holds open to multiAddr.
+	Disconnect(ctx context.Context, to identity.MultiAddress, multiAddr identity.MultiAddress) error
+
+	// Peers returns the peers that to currently considers live (recently
+	// ponged), along with when they were last seen and their RTT.
+	Peers(ctx context.Context, to identity.MultiAddress) ([]PeerInfo, error)
+
+	// KnownAddrs returns every identity.MultiAddress in to's
+	// MultiAddressStorer, regardless of liveness.
+	KnownAddrs(ctx context.Context, to identity.MultiAddress) (identity.MultiAddresses, error)
+}
+
 type swarmClient struct {
-	addr  identity.Address
-	store swarm.MultiAddressStorer
+	addr     identity.Address
+	store    swarm.MultiAddressStorer
+	alpha    int
+	k        int
+	resolver MultiaddrResolver
+	limiter  *DialLimiter
+}
+
+// NewSwarmClient returns an implementation of the SwarmAdminClient interface
+// that uses gRPC and a recycled connection pool. Queries performed by the
+// returned client use DefaultQueryAlpha concurrent lookups against a
+// shortlist of DefaultQueryK candidates. Multi-addresses are resolved with a
+// NewDNSMultiaddrResolver before every dial.
+func NewSwarmClient(store swarm.MultiAddressStorer, addr identity.Address) SwarmAdminClient {
+	return NewSwarmClientWithAlpha(store, addr, DefaultQueryAlpha)
+}
+
+// NewSwarmClientWithAlpha is the same as NewSwarmClient but allows the
+// concurrency factor, alpha, of iterative Queries to be configured.
+func NewSwarmClientWithAlpha(store swarm.MultiAddressStorer, addr identity.Address, alpha int) SwarmAdminClient {
+	return NewSwarmClientWithResolver(store, addr, alpha, NewDNSMultiaddrResolver(DefaultResolverTTL))
+}
+
+// NewSwarmClientWithResolver is the same as NewSwarmClientWithAlpha but
+// allows the MultiaddrResolver used to expand hostnames before dialling to
+// be configured. Dials are routed through defaultDialLimiter, a
+// process-wide DialLimiter sized to DefaultDialLimiterGlobalCapacity and
+// DefaultDialLimiterPeerCapacity, so that every swarmClient in this process
+// shares one FD budget.
+func NewSwarmClientWithResolver(store swarm.MultiAddressStorer, addr identity.Address, alpha int, resolver MultiaddrResolver) SwarmAdminClient {
+	return NewSwarmClientWithLimiter(store, addr, alpha, resolver, defaultDialLimiter())
+}
+
+var (
+	defaultDialLimiterOnce sync.Once
+	defaultDialLimiterInst *DialLimiter
+)
+
+// defaultDialLimiter lazily constructs the process-wide DialLimiter used by
+// the NewSwarmClient/NewSwarmClientWithAlpha/NewSwarmClientWithResolver
+// constructor chain.
+func defaultDialLimiter() *DialLimiter {
+	defaultDialLimiterOnce.Do(func() {
+		defaultDialLimiterInst = NewDialLimiter(Dial, DefaultDialLimiterGlobalCapacity, DefaultDialLimiterPeerCapacity, nil)
+	})
+	return defaultDialLimiterInst
 }
 
-// NewSwarmClient returns an implementation of the swarm.Client interface that
-// uses gRPC and a recycled connection pool.
-func NewSwarmClient(store swarm.MultiAddressStorer, addr identity.Address) swarm.Client {
+// NewSwarmClientWithLimiter is the same as NewSwarmClientWithResolver but
+// routes every dial through limiter. A nil limiter dials directly, with no
+// bound on concurrent outbound connections.
+func NewSwarmClientWithLimiter(store swarm.MultiAddressStorer, addr identity.Address, alpha int, resolver MultiaddrResolver, limiter *DialLimiter) SwarmAdminClient {
+	if alpha <= 0 {
+		alpha = DefaultQueryAlpha
+	}
+	if resolver == nil {
+		resolver = NewDNSMultiaddrResolver(DefaultResolverTTL)
+	}
 	return &swarmClient{
-		addr:  addr,
-		store: store,
+		addr:     addr,
+		store:    store,
+		alpha:    alpha,
+		k:        DefaultQueryK,
+		resolver: resolver,
+		limiter:  limiter,
 	}
 }
 
+// dialAny resolves to using client.resolver and attempts to Dial each
+// candidate identity.MultiAddress in the order returned, returning the first
+// connection that succeeds.
+func (client *swarmClient) dialAny(ctx context.Context, to identity.MultiAddress) (conn *grpcgo.ClientConn, err error) {
+	candidates, err := client.resolver.Resolve(ctx, to)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve %v: %v", to, err)
+	}
+
+	var lastErr error
+	for _, candidate := range candidates {
+		if client.limiter != nil {
+			conn, err = client.limiter.Dial(ctx, candidate.Address(), candidate)
+		} else {
+			conn, err = Dial(ctx, candidate)
+		}
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+		logger.Network(logger.LevelWarn, fmt.Sprintf("cannot dial resolved address %v: %v", candidate, err))
+	}
+	return nil, lastErr
+}
+
 // Ping implements the swarm.Client interface.
 func (client *swarmClient) Ping(ctx context.Context, to identity.MultiAddress, multiAddr identity.MultiAddress) error {
 	if multiAddr.IsNil() {
 		return ErrMultiAddressIsNil
 	}
-	conn, err := Dial(ctx, to)
+	conn, err := client.dialAny(ctx, to)
 	if err != nil {
 		logger.Network(logger.LevelError, fmt.Sprintf("cannot dial %v: %v", to, err))
 		return fmt.Errorf("cannot dial %v: %v", to, err)
@@ -67,14 +196,47 @@ func (client *swarmClient) Ping(ctx context.Context, to identity.MultiAddress, m
 		},
 	}
 
-	return Backoff(ctx, func() error {
-		_, err = NewSwarmServiceClient(conn).Ping(ctx, request)
+	var response *PingResponse
+	if err := Backoff(ctx, func() error {
+		response, err = NewSwarmServiceClient(conn).Ping(ctx, request)
 		return err
-	})
+	}); err != nil {
+		return err
+	}
+
+	return client.upsertPingResponse(to, response)
+}
+
+// upsertPingResponse verifies the signed identity.MultiAddress carried in a
+// PingResponse against the identity.Address expected of to, and, if it
+// checks out, upserts it into the local MultiAddressStorer. Upserting is
+// nonce-aware: a storer only keeps the more recent of two multi-addresses
+// for the same identity.Address, so a stale or replayed response cannot
+// clobber a fresher one.
+func (client *swarmClient) upsertPingResponse(to identity.MultiAddress, response *PingResponse) error {
+	if response.GetMultiAddress() == nil {
+		return ErrMultiAddressIsNil
+	}
+	self, err := identity.NewMultiAddressFromString(response.GetMultiAddress().GetMultiAddress())
+	if err != nil {
+		logger.Network(logger.LevelError, fmt.Sprintf("cannot unmarshal multiaddress: %v", err))
+		return fmt.Errorf("cannot unmarshal multiaddress: %v", err)
+	}
+	self.Signature = response.GetMultiAddress().GetSignature()
+	self.Nonce = response.GetMultiAddress().GetMultiAddressNonce()
+
+	if self.Address() != to.Address() {
+		return fmt.Errorf("cannot verify ping response from %v: got signed multiaddress for %v", to.Address(), self.Address())
+	}
+	if err := self.Verify(); err != nil {
+		return fmt.Errorf("cannot verify ping response from %v: %v", to.Address(), err)
+	}
+
+	return client.store.InsertMultiAddress(self)
 }
 
 func (client *swarmClient) Pong(ctx context.Context, to identity.MultiAddress) error {
-	conn, err := Dial(ctx, to)
+	conn, err := client.dialAny(ctx, to)
 	if err != nil {
 		logger.Network(logger.LevelError, fmt.Sprintf("cannot dial %v: %v", to, err))
 		return fmt.Errorf("cannot dial %v: %v", to, err)
@@ -101,12 +263,65 @@ func (client *swarmClient) Pong(ctx context.Context, to identity.MultiAddress) e
 	})
 }
 
-// Query implements the swarm.Client interface.
+// Query implements the swarm.Client interface. It performs an α-parallel
+// iterative lookup in the style of Kademlia: starting from the shortlist
+// {to}, it dispatches up to client.alpha concurrent QueryStream RPCs against
+// the closest un-queried candidates, merges the streamed results into the
+// shortlist, and repeats until a round fails to produce a candidate closer
+// to query than the current best.
 func (client *swarmClient) Query(ctx context.Context, to identity.MultiAddress, query identity.Address) (identity.MultiAddresses, error) {
 	if query == "" {
 		return identity.MultiAddresses{}, ErrAddressIsNil
 	}
-	conn, err := Dial(ctx, to)
+
+	shortlist := newShortlist(query, client.k)
+	shortlist.Insert(to)
+	queried := map[identity.Address]struct{}{}
+
+	for {
+		round := shortlist.Closest(client.alpha, queried)
+		if len(round) == 0 {
+			break
+		}
+
+		closestBefore := shortlist.ClosestDistance()
+
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+
+		for _, candidate := range round {
+			wg.Add(1)
+			go func(candidate identity.MultiAddress) {
+				defer wg.Done()
+
+				results, err := client.queryStream(ctx, candidate, query)
+
+				mu.Lock()
+				defer mu.Unlock()
+				queried[candidate.Address()] = struct{}{}
+				if err != nil {
+					logger.Network(logger.LevelWarn, fmt.Sprintf("cannot query %v: %v", candidate, err))
+					return
+				}
+				for _, result := range results {
+					shortlist.Insert(result)
+				}
+			}(candidate)
+		}
+		wg.Wait()
+
+		if shortlist.ClosestDistance().Cmp(closestBefore) >= 0 {
+			break
+		}
+	}
+
+	return shortlist.MultiAddresses(), nil
+}
+
+// queryStream dials to, opens a QueryStream RPC, and drains it into an
+// identity.MultiAddresses, bounded by the lifetime of ctx.
+func (client *swarmClient) queryStream(ctx context.Context, to identity.MultiAddress, query identity.Address) (identity.MultiAddresses, error) {
+	conn, err := client.dialAny(ctx, to)
 	if err != nil {
 		logger.Network(logger.LevelError, fmt.Sprintf("cannot dial %v: %v", to, err))
 		return identity.MultiAddresses{}, fmt.Errorf("cannot dial %v: %v", to, err)
@@ -117,16 +332,23 @@ func (client *swarmClient) Query(ctx context.Context, to identity.MultiAddress,
 		Address: query.String(),
 	}
 
-	var response *QueryResponse
+	var stream SwarmService_QueryStreamClient
 	if err := Backoff(ctx, func() error {
-		response, err = NewSwarmServiceClient(conn).Query(ctx, request)
+		stream, err = NewSwarmServiceClient(conn).QueryStream(ctx, request)
 		return err
 	}); err != nil {
 		return identity.MultiAddresses{}, err
 	}
 
 	multiAddrs := identity.MultiAddresses{}
-	for _, multiAddrMsg := range response.MultiAddresses {
+	for {
+		multiAddrMsg, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return multiAddrs, err
+		}
 		multiAddr, err := identity.NewMultiAddressFromString(multiAddrMsg.MultiAddress)
 		if err != nil {
 			logger.Network(logger.LevelWarn, fmt.Sprintf("cannot parse %v: %v", multiAddrMsg.MultiAddress, err))
@@ -149,21 +371,165 @@ func (client *swarmClient) MultiAddress() identity.MultiAddress {
 	return multiAddr
 }
 
+// Connect implements the SwarmAdminClient interface.
+func (client *swarmClient) Connect(ctx context.Context, to identity.MultiAddress, multiAddr identity.MultiAddress) error {
+	if multiAddr.IsNil() {
+		return ErrMultiAddressIsNil
+	}
+	conn, err := client.dialAny(ctx, to)
+	if err != nil {
+		logger.Network(logger.LevelError, fmt.Sprintf("cannot dial %v: %v", to, err))
+		return fmt.Errorf("cannot dial %v: %v", to, err)
+	}
+	defer conn.Close()
+
+	request := &ConnectRequest{
+		MultiAddress: &MultiAddress{
+			Signature:         multiAddr.Signature,
+			MultiAddress:      multiAddr.String(),
+			MultiAddressNonce: multiAddr.Nonce,
+		},
+	}
+
+	return Backoff(ctx, func() error {
+		_, err = NewSwarmServiceClient(conn).Connect(ctx, request)
+		return err
+	})
+}
+
+// Disconnect implements the SwarmAdminClient interface.
+func (client *swarmClient) Disconnect(ctx context.Context, to identity.MultiAddress, multiAddr identity.MultiAddress) error {
+	if multiAddr.IsNil() {
+		return ErrMultiAddressIsNil
+	}
+	conn, err := client.dialAny(ctx, to)
+	if err != nil {
+		logger.Network(logger.LevelError, fmt.Sprintf("cannot dial %v: %v", to, err))
+		return fmt.Errorf("cannot dial %v: %v", to, err)
+	}
+	defer conn.Close()
+
+	request := &DisconnectRequest{
+		MultiAddress: &MultiAddress{
+			Signature:         multiAddr.Signature,
+			MultiAddress:      multiAddr.String(),
+			MultiAddressNonce: multiAddr.Nonce,
+		},
+	}
+
+	return Backoff(ctx, func() error {
+		_, err = NewSwarmServiceClient(conn).Disconnect(ctx, request)
+		return err
+	})
+}
+
+// Peers implements the SwarmAdminClient interface.
+func (client *swarmClient) Peers(ctx context.Context, to identity.MultiAddress) ([]PeerInfo, error) {
+	conn, err := client.dialAny(ctx, to)
+	if err != nil {
+		logger.Network(logger.LevelError, fmt.Sprintf("cannot dial %v: %v", to, err))
+		return nil, fmt.Errorf("cannot dial %v: %v", to, err)
+	}
+	defer conn.Close()
+
+	var response *PeersResponse
+	if err := Backoff(ctx, func() error {
+		response, err = NewSwarmServiceClient(conn).Peers(ctx, &PeersRequest{})
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	peers := make([]PeerInfo, 0, len(response.Peers))
+	for _, peerMsg := range response.Peers {
+		multiAddr, err := identity.NewMultiAddressFromString(peerMsg.GetMultiAddress().GetMultiAddress())
+		if err != nil {
+			logger.Network(logger.LevelWarn, fmt.Sprintf("cannot parse %v: %v", peerMsg.GetMultiAddress().GetMultiAddress(), err))
+			continue
+		}
+		multiAddr.Nonce = peerMsg.GetMultiAddress().GetMultiAddressNonce()
+		multiAddr.Signature = peerMsg.GetMultiAddress().GetSignature()
+		peers = append(peers, PeerInfo{
+			MultiAddress: multiAddr,
+			LastSeen:     time.Unix(peerMsg.LastSeenUnix, 0),
+			RTT:          time.Duration(peerMsg.RttMs) * time.Millisecond,
+		})
+	}
+	return peers, nil
+}
+
+// KnownAddrs implements the SwarmAdminClient interface.
+func (client *swarmClient) KnownAddrs(ctx context.Context, to identity.MultiAddress) (identity.MultiAddresses, error) {
+	conn, err := client.dialAny(ctx, to)
+	if err != nil {
+		logger.Network(logger.LevelError, fmt.Sprintf("cannot dial %v: %v", to, err))
+		return identity.MultiAddresses{}, fmt.Errorf("cannot dial %v: %v", to, err)
+	}
+	defer conn.Close()
+
+	var response *KnownAddrsResponse
+	if err := Backoff(ctx, func() error {
+		response, err = NewSwarmServiceClient(conn).KnownAddrs(ctx, &KnownAddrsRequest{})
+		return err
+	}); err != nil {
+		return identity.MultiAddresses{}, err
+	}
+
+	multiAddrs := identity.MultiAddresses{}
+	for _, multiAddrMsg := range response.MultiAddresses {
+		multiAddr, err := identity.NewMultiAddressFromString(multiAddrMsg.MultiAddress)
+		if err != nil {
+			logger.Network(logger.LevelWarn, fmt.Sprintf("cannot parse %v: %v", multiAddrMsg.MultiAddress, err))
+			continue
+		}
+		multiAddr.Nonce = multiAddrMsg.MultiAddressNonce
+		multiAddr.Signature = multiAddrMsg.Signature
+		multiAddrs = append(multiAddrs, multiAddr)
+	}
+	return multiAddrs, nil
+}
+
 // SwarmService is a Service that implements the gRPC SwarmService defined in
 // protobuf. It delegates responsibility for handling the Ping and Query RPCs
 // to a swarm.Server.
 type SwarmService struct {
-	server swarm.Server
+	server  swarm.Server
+	limiter RateLimiter
 }
 
 // NewSwarmService returns a SwarmService that uses the swarm.Server as a
-// delegate.
+// delegate. Ping, Pong, Query, QueryStream, and the operator RPCs (Connect,
+// Disconnect, Peers, KnownAddrs) are rate-limited by a
+// NewTokenBucketRateLimiter using the package defaults.
 func NewSwarmService(server swarm.Server) SwarmService {
+	return NewSwarmServiceWithRateLimiter(server, NewTokenBucketRateLimiter(
+		DefaultRateLimiterRate, DefaultRateLimiterBurst,
+		DefaultGlobalRateLimiterRate, DefaultGlobalRateLimiterBurst,
+		DefaultRateLimiterLRUSize,
+	))
+}
+
+// NewSwarmServiceWithRateLimiter is the same as NewSwarmService but allows
+// the RateLimiter applied to Ping, Pong, Query, QueryStream, and the
+// operator RPCs to be configured. A nil limiter disables rate limiting.
+func NewSwarmServiceWithRateLimiter(server swarm.Server, limiter RateLimiter) SwarmService {
 	return SwarmService{
-		server: server,
+		server:  server,
+		limiter: limiter,
 	}
 }
 
+// allow reports whether the RPC named rpc, called by peerAddr, should be
+// processed. It emits a warn-level logger.Network event on denial so that
+// operators can tune rate limit thresholds.
+func (service *SwarmService) allow(peerAddr identity.Address, rpc string) bool {
+	if service.limiter == nil || service.limiter.Allow(peerAddr, rpc) {
+		return true
+	}
+	logger.Network(logger.LevelWarn, fmt.Sprintf("rate limit exceeded for %v on %v", peerAddr, rpc))
+	return false
+}
+
 // Register implements the Service interface.
 func (service *SwarmService) Register(server *Server) {
 	if server == nil {
@@ -195,12 +561,28 @@ func (service *SwarmService) Ping(ctx context.Context, request *PingRequest) (*P
 	from.Signature = request.GetMultiAddress().GetSignature()
 	from.Nonce = request.GetMultiAddress().GetMultiAddressNonce()
 
+	if !service.allow(from.Address(), "Ping") {
+		return nil, status.Error(codes.ResourceExhausted, ErrRateLimitExceeded.Error())
+	}
+
 	err = service.server.Ping(ctx, from)
 	if err != nil {
 		logger.Network(logger.LevelInfo, fmt.Sprintf("cannot update store with: %v", err))
 		return &PingResponse{}, fmt.Errorf("cannot update store: %v", err)
 	}
-	return &PingResponse{}, nil
+
+	self, err := service.server.MultiAddress(ctx)
+	if err != nil {
+		logger.Network(logger.LevelError, fmt.Sprintf("cannot retrieve own multiaddress: %v", err))
+		return nil, fmt.Errorf("cannot retrieve own multiaddress: %v", err)
+	}
+	return &PingResponse{
+		MultiAddress: &MultiAddress{
+			Signature:         self.Signature,
+			MultiAddress:      self.String(),
+			MultiAddressNonce: self.Nonce,
+		},
+	}, nil
 }
 
 // Pong is an RPC used to notify a SwarmService about the existence of a
@@ -226,6 +608,10 @@ func (service *SwarmService) Pong(ctx context.Context, request *PongRequest) (*P
 	from.Signature = request.GetMultiAddress().GetSignature()
 	from.Nonce = request.GetMultiAddress().GetMultiAddressNonce()
 
+	if !service.allow(from.Address(), "Pong") {
+		return nil, status.Error(codes.ResourceExhausted, ErrRateLimitExceeded.Error())
+	}
+
 	err = service.server.Pong(ctx, from)
 	if err != nil {
 		logger.Network(logger.LevelInfo, fmt.Sprintf("cannot update storer with %v: %v", request.GetMultiAddress(), err))
@@ -247,6 +633,9 @@ func (service *SwarmService) Query(ctx context.Context, request *QueryRequest) (
 	if request.Address == "" {
 		return nil, ErrAddressIsNil
 	}
+	if !service.allow(peerAddressFromContext(ctx), "Query") {
+		return nil, status.Error(codes.ResourceExhausted, ErrRateLimitExceeded.Error())
+	}
 	query := identity.Address(request.GetAddress())
 	multiAddrs, err := service.server.Query(ctx, query)
 	if err != nil {
@@ -266,3 +655,253 @@ func (service *SwarmService) Query(ctx context.Context, request *QueryRequest) (
 		MultiAddresses: multiAddrMsgs,
 	}, nil
 }
+
+// QueryStream is an RPC used to find identity.MultiAddresses. It behaves
+// like Query but sends each identity.MultiAddress to the client as a
+// separate stream message, instead of packing them all into a single
+// response. The SwarmService delegates responsibility to its swarm.Server to
+// return identity.MultiAddresses that are close to the queried
+// identity.Address; the swarm.Server's full result is collected before the
+// first message is sent, so results are not streamed as they are found.
+func (service *SwarmService) QueryStream(request *QueryRequest, stream SwarmService_QueryStreamServer) error {
+	// Check for empty or invalid request fields.
+	if request == nil {
+		return ErrQueryRequestIsNil
+	}
+	if request.Address == "" {
+		return ErrAddressIsNil
+	}
+	if !service.allow(peerAddressFromContext(stream.Context()), "QueryStream") {
+		return status.Error(codes.ResourceExhausted, ErrRateLimitExceeded.Error())
+	}
+	query := identity.Address(request.GetAddress())
+
+	multiAddrs, err := service.server.Query(stream.Context(), query)
+	if err != nil {
+		return err
+	}
+
+	for _, multiAddr := range multiAddrs {
+		multiAddrMsg := &MultiAddress{
+			MultiAddress:      multiAddr.String(),
+			Signature:         multiAddr.Signature,
+			MultiAddressNonce: multiAddr.Nonce,
+		}
+		if err := stream.Send(multiAddrMsg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Connect is an operator RPC used to instruct a SwarmService to immediately
+// dial and Ping the identity.MultiAddress carried in the request. Unlike
+// Ping, the request does not need to originate from the dialed peer; it
+// lets an operator deterministically rewire a node's view of the swarm.
+func (service *SwarmService) Connect(ctx context.Context, request *ConnectRequest) (*ConnectResponse, error) {
+	if err := authorizeAdmin(ctx); err != nil {
+		logger.Network(logger.LevelWarn, fmt.Sprintf("rejecting Connect: %v", err))
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	if !service.allow(peerAddressFromContext(ctx), "Connect") {
+		return nil, status.Error(codes.ResourceExhausted, ErrRateLimitExceeded.Error())
+	}
+	if request == nil || request.MultiAddress == nil {
+		return nil, ErrMultiAddressIsNil
+	}
+	multiAddr, err := identity.NewMultiAddressFromString(request.GetMultiAddress().GetMultiAddress())
+	if err != nil {
+		logger.Network(logger.LevelError, fmt.Sprintf("cannot unmarshal multiaddress: %v", err))
+		return nil, fmt.Errorf("cannot unmarshal multiaddress: %v", err)
+	}
+	multiAddr.Signature = request.GetMultiAddress().GetSignature()
+	multiAddr.Nonce = request.GetMultiAddress().GetMultiAddressNonce()
+
+	if err := service.server.Connect(ctx, multiAddr); err != nil {
+		logger.Network(logger.LevelInfo, fmt.Sprintf("cannot connect to %v: %v", multiAddr, err))
+		return &ConnectResponse{}, fmt.Errorf("cannot connect to %v: %v", multiAddr, err)
+	}
+	return &ConnectResponse{}, nil
+}
+
+// Disconnect is an operator RPC used to instruct a SwarmService to evict the
+// identity.MultiAddress carried in the request from its MultiAddressStorer
+// and close any pooled connection it holds open to that peer.
+func (service *SwarmService) Disconnect(ctx context.Context, request *DisconnectRequest) (*DisconnectResponse, error) {
+	if err := authorizeAdmin(ctx); err != nil {
+		logger.Network(logger.LevelWarn, fmt.Sprintf("rejecting Disconnect: %v", err))
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	if !service.allow(peerAddressFromContext(ctx), "Disconnect") {
+		return nil, status.Error(codes.ResourceExhausted, ErrRateLimitExceeded.Error())
+	}
+	if request == nil || request.MultiAddress == nil {
+		return nil, ErrMultiAddressIsNil
+	}
+	multiAddr, err := identity.NewMultiAddressFromString(request.GetMultiAddress().GetMultiAddress())
+	if err != nil {
+		logger.Network(logger.LevelError, fmt.Sprintf("cannot unmarshal multiaddress: %v", err))
+		return nil, fmt.Errorf("cannot unmarshal multiaddress: %v", err)
+	}
+
+	if err := service.server.Disconnect(ctx, multiAddr.Address()); err != nil {
+		logger.Network(logger.LevelInfo, fmt.Sprintf("cannot disconnect from %v: %v", multiAddr, err))
+		return &DisconnectResponse{}, fmt.Errorf("cannot disconnect from %v: %v", multiAddr, err)
+	}
+	return &DisconnectResponse{}, nil
+}
+
+// Peers is an operator RPC that returns the peers the SwarmService's
+// swarm.Server currently considers live (recently ponged), along with their
+// last-seen time and RTT.
+func (service *SwarmService) Peers(ctx context.Context, request *PeersRequest) (*PeersResponse, error) {
+	if err := authorizeAdmin(ctx); err != nil {
+		logger.Network(logger.LevelWarn, fmt.Sprintf("rejecting Peers: %v", err))
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	if !service.allow(peerAddressFromContext(ctx), "Peers") {
+		return nil, status.Error(codes.ResourceExhausted, ErrRateLimitExceeded.Error())
+	}
+	peers, err := service.server.Peers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	peerMsgs := make([]*Peer, len(peers))
+	for i, peer := range peers {
+		peerMsgs[i] = &Peer{
+			MultiAddress: &MultiAddress{
+				MultiAddress:      peer.MultiAddress.String(),
+				Signature:         peer.MultiAddress.Signature,
+				MultiAddressNonce: peer.MultiAddress.Nonce,
+			},
+			LastSeenUnix: peer.LastSeen.Unix(),
+			RttMs:        peer.RTT.Nanoseconds() / int64(time.Millisecond),
+		}
+	}
+	return &PeersResponse{Peers: peerMsgs}, nil
+}
+
+// KnownAddrs is an operator RPC that returns every identity.MultiAddress in
+// the SwarmService's swarm.Server's MultiAddressStorer, regardless of
+// liveness.
+func (service *SwarmService) KnownAddrs(ctx context.Context, request *KnownAddrsRequest) (*KnownAddrsResponse, error) {
+	if err := authorizeAdmin(ctx); err != nil {
+		logger.Network(logger.LevelWarn, fmt.Sprintf("rejecting KnownAddrs: %v", err))
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	if !service.allow(peerAddressFromContext(ctx), "KnownAddrs") {
+		return nil, status.Error(codes.ResourceExhausted, ErrRateLimitExceeded.Error())
+	}
+	multiAddrs, err := service.server.KnownAddrs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	multiAddrMsgs := make([]*MultiAddress, len(multiAddrs))
+	for i, multiAddr := range multiAddrs {
+		multiAddrMsgs[i] = &MultiAddress{
+			MultiAddress:      multiAddr.String(),
+			Signature:         multiAddr.Signature,
+			MultiAddressNonce: multiAddr.Nonce,
+		}
+	}
+	return &KnownAddrsResponse{MultiAddresses: multiAddrMsgs}, nil
+}
+
+// shortlist is a bounded, XOR-distance-sorted set of identity.MultiAddresses
+// used to drive an iterative lookup towards a target identity.Address. It is
+// not safe for concurrent use; callers must synchronize their own access.
+type shortlist struct {
+	target identity.Address
+	k      int
+	seen   map[identity.Address]struct{}
+	addrs  identity.MultiAddresses
+}
+
+func newShortlist(target identity.Address, k int) *shortlist {
+	if k <= 0 {
+		k = DefaultQueryK
+	}
+	return &shortlist{
+		target: target,
+		k:      k,
+		seen:   map[identity.Address]struct{}{},
+		addrs:  identity.MultiAddresses{},
+	}
+}
+
+// Insert adds multiAddr to the shortlist, keeping only the k closest
+// identity.MultiAddresses to the target. It returns true if multiAddr was
+// not previously in the shortlist.
+func (list *shortlist) Insert(multiAddr identity.MultiAddress) bool {
+	addr := multiAddr.Address()
+	if _, ok := list.seen[addr]; ok {
+		return false
+	}
+	list.seen[addr] = struct{}{}
+	list.addrs = append(list.addrs, multiAddr)
+
+	sort.Slice(list.addrs, func(i, j int) bool {
+		return closer(list.target, list.addrs[i].Address(), list.addrs[j].Address())
+	})
+	if len(list.addrs) > list.k {
+		for _, dropped := range list.addrs[list.k:] {
+			delete(list.seen, dropped.Address())
+		}
+		list.addrs = list.addrs[:list.k]
+	}
+	return true
+}
+
+// Closest returns up to n identity.MultiAddresses from the shortlist that
+// are not present in queried, ordered by ascending distance to the target.
+func (list *shortlist) Closest(n int, queried map[identity.Address]struct{}) identity.MultiAddresses {
+	candidates := identity.MultiAddresses{}
+	for _, multiAddr := range list.addrs {
+		if _, ok := queried[multiAddr.Address()]; ok {
+			continue
+		}
+		candidates = append(candidates, multiAddr)
+		if len(candidates) == n {
+			break
+		}
+	}
+	return candidates
+}
+
+// MultiAddresses returns the shortlist's current contents, closest first.
+func (list *shortlist) MultiAddresses() identity.MultiAddresses {
+	return list.addrs
+}
+
+// ClosestDistance returns the XOR distance from the target to the closest
+// identity.MultiAddress currently in the shortlist. If the shortlist is
+// empty, it returns maxXORDistance, a value no real distance can exceed, so
+// that the first round of a lookup is never mistaken for convergence.
+func (list *shortlist) ClosestDistance() *big.Int {
+	if len(list.addrs) == 0 {
+		return maxXORDistance
+	}
+	return xorDistance(list.target, list.addrs[0].Address())
+}
+
+// closer returns true if a is closer to target than b, under the XOR
+// metric computed over the SHA256 digests of the respective addresses.
+func closer(target, a, b identity.Address) bool {
+	return xorDistance(target, a).Cmp(xorDistance(target, b)) < 0
+}
+
+// maxXORDistance is one past the largest possible SHA256-based XOR
+// distance; see xorDistance.
+var maxXORDistance = new(big.Int).Lsh(big.NewInt(1), sha256.Size*8)
+
+func xorDistance(target, addr identity.Address) *big.Int {
+	targetHash := sha256.Sum256([]byte(target))
+	addrHash := sha256.Sum256([]byte(addr))
+	distance := make([]byte, len(targetHash))
+	for i := range targetHash {
+		distance[i] = targetHash[i] ^ addrHash[i]
+	}
+	return new(big.Int).SetBytes(distance)
+}
@@ -0,0 +1,66 @@
+package grpc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/republicprotocol/republic-go/identity"
+)
+
+// fakeMultiAddressStorer is a minimal swarm.MultiAddressStorer used to
+// confirm upsertPingResponse does, or does not, reach the store.
+type fakeMultiAddressStorer struct {
+	inserted *identity.MultiAddress
+}
+
+func (storer *fakeMultiAddressStorer) InsertMultiAddress(multiAddr identity.MultiAddress) error {
+	storer.inserted = &multiAddr
+	return nil
+}
+
+func (storer *fakeMultiAddressStorer) MultiAddress(addr identity.Address) (identity.MultiAddress, error) {
+	if storer.inserted == nil {
+		return identity.MultiAddress{}, errors.New("no multiaddress stored")
+	}
+	return *storer.inserted, nil
+}
+
+func TestUpsertPingResponseRejectsAddressMismatch(t *testing.T) {
+	to := mustMultiAddress(t, identity.Address("to"))
+	impersonated := mustMultiAddress(t, identity.Address("someone-else"))
+	storer := &fakeMultiAddressStorer{}
+	client := &swarmClient{store: storer}
+
+	response := &PingResponse{
+		MultiAddress: &MultiAddress{
+			MultiAddress: impersonated.String(),
+		},
+	}
+
+	if err := client.upsertPingResponse(to, response); err == nil {
+		t.Fatal("expected a response signed for a different identity.Address to be rejected")
+	}
+	if storer.inserted != nil {
+		t.Fatal("expected the mismatched multiaddress not to be upserted into the store")
+	}
+}
+
+func TestUpsertPingResponseRejectsFailedVerification(t *testing.T) {
+	to := mustMultiAddress(t, identity.Address("to"))
+	storer := &fakeMultiAddressStorer{}
+	client := &swarmClient{store: storer}
+
+	response := &PingResponse{
+		MultiAddress: &MultiAddress{
+			MultiAddress: to.String(),
+			Signature:    []byte("not-a-real-signature"),
+		},
+	}
+
+	if err := client.upsertPingResponse(to, response); err == nil {
+		t.Fatal("expected a response with an invalid signature to be rejected")
+	}
+	if storer.inserted != nil {
+		t.Fatal("expected the unverifiable multiaddress not to be upserted into the store")
+	}
+}